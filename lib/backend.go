@@ -0,0 +1,43 @@
+package httpcurl
+
+import (
+	"fmt"
+	"time"
+)
+
+// Backend executes a CurlOption request and returns the response body (or,
+// for the curl subprocess backend, its combined stdout/stderr).
+type Backend interface {
+	Execute(options CurlOption, timeout time.Duration) (string, error)
+}
+
+// SessionBackend is implemented by backends that can run a request with a
+// persistent cookie jar, loading cookies from cookieFile before the request
+// and writing any updated ones back after. cookieFile is in curl's
+// Netscape cookie-jar format, so it's interchangeable between backends.
+type SessionBackend interface {
+	Backend
+	ExecuteWithSession(options CurlOption, timeout time.Duration, cookieFile string) (string, error)
+}
+
+// Backend names accepted by ResolveBackend and the /curl?backend= query
+// param.
+const (
+	BackendCurl   = "curl"
+	BackendNative = "native"
+)
+
+// ResolveBackend returns the Backend for the given name, defaulting to the
+// curl subprocess backend when name is empty. It returns an error for any
+// other unrecognized name. A nil policy makes the backend fall back to
+// DefaultHostPolicy().
+func ResolveBackend(name string, policy *HostPolicy) (Backend, error) {
+	switch name {
+	case "", BackendCurl:
+		return &CurlBinaryBackend{policy: policy}, nil
+	case BackendNative:
+		return NewNativeBackendWithPolicy(policy), nil
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", name)
+	}
+}
@@ -0,0 +1,114 @@
+package httpcurl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBackend(t *testing.T) {
+	backend, err := ResolveBackend("", nil)
+	require.NoError(t, err)
+	assert.IsType(t, &CurlBinaryBackend{}, backend)
+
+	backend, err = ResolveBackend(BackendCurl, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &CurlBinaryBackend{}, backend)
+
+	backend, err = ResolveBackend(BackendNative, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &NativeBackend{}, backend)
+
+	_, err = ResolveBackend("bogus", nil)
+	assert.Error(t, err)
+}
+
+func TestNativeBackend_GetRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	options := CurlOption{
+		"--location": CurlValue{server.URL},
+	}
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	output, err := backend.Execute(options, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", output)
+}
+
+func TestNativeBackend_POSTWithHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"test":"data"}`, string(body))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	options := CurlOption{
+		"-X":         CurlValue{"POST"},
+		"-d":         CurlValue{`{"test":"data"}`},
+		"-H":         CurlValue{"Content-Type: application/json"},
+		"--location": CurlValue{server.URL},
+	}
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	output, err := backend.Execute(options, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, `{"test":"data"}`, output)
+}
+
+func TestNativeBackend_FollowsRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("final"))
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	options := CurlOption{
+		"--location": CurlValue{server.URL},
+	}
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	output, err := backend.Execute(options, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "final", output)
+}
+
+func TestBackends_Equivalent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	options := CurlOption{
+		"--location": CurlValue{server.URL},
+	}
+
+	curlOutput, err := (&CurlBinaryBackend{policy: testLoopbackPolicy(t)}).Execute(options, 5*time.Second)
+	require.NoError(t, err)
+
+	nativeBackend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	nativeOutput, err := nativeBackend.Execute(options, 5*time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, curlOutput, nativeOutput)
+}
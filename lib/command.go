@@ -0,0 +1,191 @@
+package httpcurl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// optionAliases maps common curl/browser-devtools/Postman spellings to the
+// canonical AllowedCurlOptions flag ParseCommand stores values under, so a
+// command copied verbatim from "Copy as cURL" or a Postman code snippet
+// isn't rejected just for spelling a flag differently than this package's
+// JSON form does.
+var optionAliases = map[string]string{
+	"-L":         "--location",
+	"--url":      "--location",
+	"--header":   "-H",
+	"--request":  "-X",
+	"--data-raw": "--data",
+}
+
+// optionsWithArgument lists the curl flags, spelled the way they're typed
+// on the command line (i.e. before optionAliases resolves them), that
+// consume the token following them (as opposed to boolean flags like -k or
+// --location/-L, which only toggle redirect-following; the target URL is
+// always the bare positional argument, or --url when given explicitly).
+var optionsWithArgument = map[string]bool{
+	"-X":         true,
+	"-d":         true,
+	"--data":     true,
+	"-H":         true,
+	"-x":         true,
+	"--url":      true,
+	"--header":   true,
+	"--request":  true,
+	"--data-raw": true,
+}
+
+// ParseCommand parses a raw curl command line (e.g. copied from browser
+// devtools or Postman) into a CurlOption map, so it can be run through
+// sanitizeInput/HttpCurl the same way as the structured JSON form. Every
+// flag is checked against AllowedCurlOptions; any token starting with "-"
+// that isn't on the whitelist is rejected. Bare positional arguments (the
+// URL) are recorded under "--location", matching the convention used
+// elsewhere in this package.
+func ParseCommand(cmd string) (CurlOption, error) {
+	tokens, err := splitShellWords(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("parsing command: %w", err)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	if tokens[0] == "curl" {
+		tokens = tokens[1:]
+	}
+
+	options := CurlOption{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if !strings.HasPrefix(token, "-") || token == "-" {
+			options["--location"] = append(options["--location"], token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(token, "=")
+		argTaking := optionsWithArgument[name]
+
+		if canonical, ok := optionAliases[name]; ok {
+			name = canonical
+		}
+		if !AllowedCurlOptions[name] {
+			return nil, fmt.Errorf("unauthorized curl option: %s", name)
+		}
+
+		if !hasValue && argTaking {
+			i++
+			if i >= len(tokens) {
+				return nil, fmt.Errorf("option %s requires a value", name)
+			}
+			value = tokens[i]
+			hasValue = true
+		}
+
+		if !hasValue {
+			// A bare --location/-L is curl's boolean "follow redirects"
+			// toggle, not a value; the target URL always comes in as the
+			// positional argument (or --url, handled above via
+			// optionsWithArgument) instead, so there's nothing to record.
+			if name == "--location" {
+				continue
+			}
+			value = "true"
+		}
+
+		options[name] = append(options[name], value)
+	}
+
+	return options, nil
+}
+
+// splitShellWords splits s the way a POSIX shell would: single quotes are
+// literal, double quotes allow \" \\ \$ \` escapes, backslash escapes the
+// next character outside quotes, and $'...' is ANSI-C quoting (\n, \t, \r,
+// \\, \' expand; anything else is taken literally).
+func splitShellWords(s string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	inWord := false
+	runes := []rune(s)
+	n := len(runes)
+
+	for i := 0; i < n; i++ {
+		c := runes[i]
+		switch {
+		case c == '\'':
+			inWord = true
+			i++
+			start := i
+			for i < n && runes[i] != '\'' {
+				i++
+			}
+			if i >= n {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			cur.WriteString(string(runes[start:i]))
+
+		case c == '"':
+			inWord = true
+			i++
+			for i < n && runes[i] != '"' {
+				if runes[i] == '\\' && i+1 < n && strings.ContainsRune(`"\$`+"`", runes[i+1]) {
+					i++
+				}
+				cur.WriteRune(runes[i])
+				i++
+			}
+			if i >= n {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+
+		case c == '$' && i+1 < n && runes[i+1] == '\'':
+			inWord = true
+			i += 2
+			for i < n && runes[i] != '\'' {
+				if runes[i] == '\\' && i+1 < n {
+					i++
+					switch runes[i] {
+					case 'n':
+						cur.WriteRune('\n')
+					case 't':
+						cur.WriteRune('\t')
+					case 'r':
+						cur.WriteRune('\r')
+					default:
+						cur.WriteRune(runes[i])
+					}
+				} else {
+					cur.WriteRune(runes[i])
+				}
+				i++
+			}
+			if i >= n {
+				return nil, fmt.Errorf("unterminated $'...' quote")
+			}
+
+		case c == '\\':
+			inWord = true
+			if i+1 < n {
+				i++
+				cur.WriteRune(runes[i])
+			}
+
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+
+		default:
+			inWord = true
+			cur.WriteRune(c)
+		}
+	}
+
+	if inWord {
+		tokens = append(tokens, cur.String())
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,86 @@
+package httpcurl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommand_SimpleGet(t *testing.T) {
+	options, err := ParseCommand("curl https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"https://example.com"}, options["--location"])
+}
+
+func TestParseCommand_ExplicitLocationFlag(t *testing.T) {
+	options, err := ParseCommand("curl --location 'https://example.com'")
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"https://example.com"}, options["--location"])
+}
+
+func TestParseCommand_BrowserDevtoolsAliases(t *testing.T) {
+	cmd := `curl -L --request POST --header 'Content-Type: application/json' --data-raw '{"a":1}' 'https://example.com'`
+	options, err := ParseCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"https://example.com"}, options["--location"])
+	assert.Equal(t, CurlValue{"POST"}, options["-X"])
+	assert.Equal(t, CurlValue{"Content-Type: application/json"}, options["-H"])
+	assert.Equal(t, CurlValue{`{"a":1}`}, options["--data"])
+}
+
+func TestParseCommand_URLFlag(t *testing.T) {
+	options, err := ParseCommand("curl --url https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"https://example.com"}, options["--location"])
+}
+
+func TestParseCommand_POSTWithHeadersAndData(t *testing.T) {
+	cmd := `curl -X POST -H 'Content-Type: application/json' -d '{"test":"data"}' https://example.com`
+	options, err := ParseCommand(cmd)
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"POST"}, options["-X"])
+	assert.Equal(t, CurlValue{"Content-Type: application/json"}, options["-H"])
+	assert.Equal(t, CurlValue{`{"test":"data"}`}, options["-d"])
+	assert.Equal(t, CurlValue{"https://example.com"}, options["--location"])
+}
+
+func TestParseCommand_LongFlagWithEquals(t *testing.T) {
+	options, err := ParseCommand(`curl --data='{"a":1}' https://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{`{"a":1}`}, options["--data"])
+}
+
+func TestParseCommand_BooleanFlag(t *testing.T) {
+	options, err := ParseCommand("curl -k https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"true"}, options["-k"])
+}
+
+func TestParseCommand_UnauthorizedOption(t *testing.T) {
+	_, err := ParseCommand("curl --output /etc/passwd https://example.com")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthorized curl option")
+}
+
+func TestParseCommand_DollarSingleQuoteEscapes(t *testing.T) {
+	options, err := ParseCommand(`curl -d $'line1\nline2' https://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{"line1\nline2"}, options["-d"])
+}
+
+func TestParseCommand_DoubleQuotesWithEscapedQuote(t *testing.T) {
+	options, err := ParseCommand(`curl -d "{\"a\":\"b\"}" https://example.com`)
+	require.NoError(t, err)
+	assert.Equal(t, CurlValue{`{"a":"b"}`}, options["-d"])
+}
+
+func TestParseCommand_EmptyCommand(t *testing.T) {
+	_, err := ParseCommand("")
+	assert.Error(t, err)
+}
+
+func TestSplitShellWords_UnterminatedQuote(t *testing.T) {
+	_, err := splitShellWords("curl 'unterminated")
+	assert.Error(t, err)
+}
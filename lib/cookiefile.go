@@ -0,0 +1,107 @@
+package httpcurl
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// netscapeCookieFileHeader marks a file as curl's cookie-jar format, the
+// same one written by curl -c and read by curl -b, so a session's cookies
+// stay interchangeable between the native and curl subprocess backends.
+const netscapeCookieFileHeader = "# Netscape HTTP Cookie File"
+
+// readNetscapeCookieFile parses a curl-format cookie jar file. A missing
+// file isn't an error: it just means the session has no cookies yet.
+func readNetscapeCookieFile(path string) ([]*http.Cookie, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cookie file: %w", err)
+	}
+	defer f.Close()
+
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+
+		expiresUnix, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		// An expiry of 0 is the Netscape-format convention for a session
+		// cookie with no expiration; leave Expires as the zero Time rather
+		// than Unix(0, 0), which would read back as already expired.
+		var expires time.Time
+		if expiresUnix != 0 {
+			expires = time.Unix(expiresUnix, 0)
+		}
+
+		cookies = append(cookies, &http.Cookie{
+			Domain:  fields[0],
+			Path:    fields[2],
+			Secure:  fields[3] == "TRUE",
+			Expires: expires,
+			Name:    fields[5],
+			Value:   fields[6],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading cookie file: %w", err)
+	}
+
+	return cookies, nil
+}
+
+// writeNetscapeCookieFile writes cookies to filePath in curl's cookie-jar
+// format. defaultDomain fills in the domain for cookies that don't carry
+// one of their own (e.g. ones read back off an *http.Cookie built from a
+// Set-Cookie header without an explicit Domain attribute).
+func writeNetscapeCookieFile(filePath, defaultDomain string, cookies []*http.Cookie) error {
+	var b strings.Builder
+	b.WriteString(netscapeCookieFileHeader + "\n")
+	for _, c := range cookies {
+		domain := c.Domain
+		if domain == "" {
+			domain = defaultDomain
+		}
+		includeSubdomains := "FALSE"
+		if strings.HasPrefix(domain, ".") {
+			includeSubdomains = "TRUE"
+		}
+		cookiePath := c.Path
+		if cookiePath == "" {
+			cookiePath = "/"
+		}
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, includeSubdomains, cookiePath, secure, expires, c.Name, c.Value)
+	}
+
+	if err := os.WriteFile(filePath, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("writing cookie file: %w", err)
+	}
+	return nil
+}
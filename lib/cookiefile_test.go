@@ -0,0 +1,38 @@
+package httpcurl
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetscapeCookieFile_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies")
+	cookies := []*http.Cookie{
+		{Name: "session", Value: "abc123", Path: "/", Expires: time.Unix(1999999999, 0)},
+		{Name: "pref", Value: "dark-mode", Domain: ".example.com"},
+	}
+
+	require.NoError(t, writeNetscapeCookieFile(path, "example.com", cookies))
+
+	got, err := readNetscapeCookieFile(path)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	assert.Equal(t, "session", got[0].Name)
+	assert.Equal(t, "abc123", got[0].Value)
+	assert.Equal(t, "example.com", got[0].Domain)
+
+	assert.Equal(t, "pref", got[1].Name)
+	assert.Equal(t, ".example.com", got[1].Domain)
+}
+
+func TestReadNetscapeCookieFile_MissingFileIsNotAnError(t *testing.T) {
+	cookies, err := readNetscapeCookieFile(filepath.Join(t.TempDir(), "does-not-exist"))
+	require.NoError(t, err)
+	assert.Nil(t, cookies)
+}
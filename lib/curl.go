@@ -0,0 +1,182 @@
+// Package httpcurl shells out to the system curl binary to perform HTTP
+// requests on behalf of the API, translating a whitelisted set of curl
+// flags into a subprocess invocation.
+package httpcurl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CurlValue holds the value(s) passed for a single curl option. Options like
+// -H may be repeated, so the JSON payload may provide either a single string
+// or an array of strings for the same key.
+type CurlValue []string
+
+// UnmarshalJSON accepts either a bare string or an array of strings so
+// callers don't have to wrap single values in an array.
+func (cv *CurlValue) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*cv = CurlValue{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*cv = CurlValue(multiple)
+	return nil
+}
+
+// CurlOption maps a curl flag (e.g. "-X", "--location") to its value(s).
+type CurlOption map[string]CurlValue
+
+// AllowedCurlOptions is the whitelist of curl flags the API will forward to
+// the curl subprocess. Anything not listed here is rejected by
+// sanitizeInput, since this endpoint is effectively a generic HTTP proxy and
+// flags like --output or --cert can be used to read/write arbitrary files.
+var AllowedCurlOptions = map[string]bool{
+	"-k":         true, // skip SSL verification
+	"-x":         true, // HTTP proxy
+	"-X":         true, // HTTP method
+	"-d":         true, // data payload
+	"--data":     true, // data payload (alternative)
+	"--location": true, // the request's target URL
+	"-H":         true, // HTTP headers
+}
+
+// sanitizeInput validates that every option is in AllowedCurlOptions and
+// flattens the option map into a curl argument list. A value of "" or
+// "true" is treated as a boolean flag (e.g. -k) and emitted without a
+// following argument.
+func sanitizeInput(input CurlOption) ([]string, error) {
+	args := make([]string, 0, len(input)*2)
+
+	for option, values := range input {
+		if !AllowedCurlOptions[option] {
+			return nil, fmt.Errorf("unauthorized curl option: %s", option)
+		}
+
+		for _, value := range values {
+			if value == "" || value == "true" {
+				args = append(args, option)
+				continue
+			}
+			args = append(args, option, value)
+		}
+	}
+
+	return args, nil
+}
+
+// noRedirectsArgs disables curl's automatic redirect-following. --location
+// (the flag that also carries the target URL, see AllowedCurlOptions)
+// normally makes curl follow redirects itself, re-resolving and connecting
+// to each hop's host with no SSRF check at all: resolveAndPin only pins the
+// original host, so a redirect to e.g. 169.254.169.254 would sail straight
+// past the policy. Unlike NativeBackend, which revalidates every dial
+// (including redirects) via safeDialContext, the curl subprocess has no
+// equivalent hook - so instead of following redirects blindly, curl is told
+// to refuse them outright (curl exits with an error on the first 3xx),
+// returning the policy-checked response for the original URL only.
+var noRedirectsArgs = []string{"--max-redirs", "0"}
+
+// HttpCurl runs curl with the given options and returns its combined
+// stdout/stderr output. The subprocess is killed if it runs longer than
+// timeout. It is equivalent to (&CurlBinaryBackend{}).Execute and is kept
+// as a package-level function for callers that don't care about backend
+// selection.
+func HttpCurl(options CurlOption, timeout time.Duration) (string, error) {
+	return runCurl(options, timeout, DefaultHostPolicy())
+}
+
+func runCurl(options CurlOption, timeout time.Duration, policy *HostPolicy, extraArgs ...string) (string, error) {
+	args, err := sanitizeInput(options)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if targetURL := firstValue(options, "--location"); targetURL != "" {
+		pinArgs, err := resolveAndPin(ctx, policy, targetURL)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, pinArgs...)
+	}
+	args = append(args, noRedirectsArgs...)
+	args = append(args, extraArgs...)
+
+	cmd := exec.CommandContext(ctx, "curl", append([]string{"-s"}, args...)...)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("request timed out after %s: %w", timeout, ctx.Err())
+	}
+	if err != nil {
+		return string(output), fmt.Errorf("curl command failed: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// CurlBinaryBackend runs requests by shelling out to the system curl
+// binary. It is the original, default Backend implementation. A nil
+// policy falls back to DefaultHostPolicy(); tests override it to permit
+// the loopback addresses httptest servers run on.
+type CurlBinaryBackend struct {
+	policy *HostPolicy
+}
+
+// Execute implements Backend.
+func (b *CurlBinaryBackend) Execute(options CurlOption, timeout time.Duration) (string, error) {
+	return runCurl(options, timeout, b.policyOrDefault())
+}
+
+// ExecuteWithSession implements SessionBackend by pointing curl's -b/-c
+// flags at cookieFile, so curl itself loads and persists the jar.
+func (b *CurlBinaryBackend) ExecuteWithSession(options CurlOption, timeout time.Duration, cookieFile string) (string, error) {
+	return runCurl(options, timeout, b.policyOrDefault(), "-b", cookieFile, "-c", cookieFile)
+}
+
+// curlStatusMarker prefixes the HTTP status code curl appends to its
+// output via -w, so executeWithStatus can split it back off the body.
+const curlStatusMarker = "__HTTPCURL_STATUS__"
+
+// executeWithStatus implements statusBackend, letting ExecuteWithRetry
+// evaluate retry_on=5xx. It asks curl to write the response status code
+// after the body, tagged with curlStatusMarker so it can be told apart
+// from the body even if the body itself ends without a trailing newline.
+func (b *CurlBinaryBackend) executeWithStatus(options CurlOption, timeout time.Duration) (string, int, error) {
+	output, err := runCurl(options, timeout, b.policyOrDefault(), "-w", "\n"+curlStatusMarker+"%{http_code}")
+
+	idx := strings.LastIndex(output, curlStatusMarker)
+	if idx == -1 {
+		return output, 0, err
+	}
+
+	body := strings.TrimSuffix(output[:idx], "\n")
+	statusCode, convErr := strconv.Atoi(strings.TrimSpace(output[idx+len(curlStatusMarker):]))
+	if convErr != nil {
+		return body, 0, err
+	}
+
+	return body, statusCode, err
+}
+
+func (b *CurlBinaryBackend) policyOrDefault() *HostPolicy {
+	if b.policy != nil {
+		return b.policy
+	}
+	return DefaultHostPolicy()
+}
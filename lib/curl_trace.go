@@ -0,0 +1,153 @@
+package httpcurl
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceMarker prefixes the write-out line curl appends after the response
+// body, so it can be split back off deterministically even if the body
+// itself contains newlines.
+const traceMarker = "__HTTPCURL_TRACE__"
+
+// traceWriteOutFormat mirrors httptrace's DNS/connect/TLS/server-processing
+// breakdown using curl's own timers (all in seconds; converted to ms after
+// parsing).
+const traceWriteOutFormat = "\n" + traceMarker + "%{time_namelookup}|%{time_connect}|%{time_appconnect}|%{time_starttransfer}|%{time_total}|%{http_code}|%{remote_ip}:%{remote_port}\n"
+
+// ExecuteWithTrace implements TracingBackend. It runs curl with -i (to dump
+// response headers) and -w traceWriteOutFormat (to dump timings), then
+// splits the combined output back into body, headers and Trace.
+func (b *CurlBinaryBackend) ExecuteWithTrace(options CurlOption, timeout time.Duration) (string, *Trace, error) {
+	args, err := sanitizeInput(options)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if targetURL := firstValue(options, "--location"); targetURL != "" {
+		pinArgs, err := resolveAndPin(ctx, b.policyOrDefault(), targetURL)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, pinArgs...)
+	}
+	args = append(args, noRedirectsArgs...)
+
+	cmdArgs := append([]string{"-s", "-i", "-w", traceWriteOutFormat}, args...)
+	cmd := exec.CommandContext(ctx, "curl", cmdArgs...)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", nil, fmt.Errorf("request timed out after %s: %w", timeout, ctx.Err())
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("curl command failed: %w", err)
+	}
+
+	raw := string(output)
+	markerIdx := strings.LastIndex(raw, traceMarker)
+	if markerIdx == -1 {
+		return "", nil, fmt.Errorf("curl trace write-out missing from output")
+	}
+
+	// traceWriteOutFormat starts with its own "\n" so the marker always
+	// lands at the start of a line; trim that one back off.
+	rawBody := strings.TrimSuffix(raw[:markerIdx], "\n")
+	traceLine := strings.TrimSpace(raw[markerIdx+len(traceMarker):])
+
+	headers, body := splitHeadersAndBody(rawBody)
+
+	trace, err := parseTraceLine(traceLine)
+	if err != nil {
+		return "", nil, err
+	}
+	trace.ResponseHeaders = headers
+
+	return body, trace, nil
+}
+
+// splitHeadersAndBody separates the last HTTP header block from the
+// response body, keeping only the final block in case curl ever emits more
+// than one (e.g. a 100-continue interim response).
+func splitHeadersAndBody(raw string) (map[string][]string, string) {
+	sep := "\r\n\r\n"
+	idx := strings.LastIndex(raw, sep)
+	if idx == -1 {
+		sep = "\n\n"
+		idx = strings.LastIndex(raw, sep)
+	}
+	if idx == -1 {
+		return map[string][]string{}, raw
+	}
+
+	headerBlock, body := raw[:idx], raw[idx+len(sep):]
+
+	// -i prints a header block per redirect hop; keep only the final one.
+	if last := strings.LastIndex(headerBlock, sep); last != -1 {
+		headerBlock = headerBlock[last+len(sep):]
+	}
+
+	headers := map[string][]string{}
+	for _, line := range strings.Split(headerBlock, "\n") {
+		line = strings.TrimRight(line, "\r")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		headers[key] = append(headers[key], value)
+	}
+
+	return headers, body
+}
+
+// parseTraceLine parses the pipe-delimited write-out line produced by
+// traceWriteOutFormat into a Trace.
+func parseTraceLine(line string) (*Trace, error) {
+	fields := strings.Split(line, "|")
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("unexpected curl trace format: %q", line)
+	}
+
+	seconds := make([]float64, 5)
+	for i := 0; i < 5; i++ {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing curl trace timing %q: %w", fields[i], err)
+		}
+		seconds[i] = v
+	}
+
+	statusCode, err := strconv.Atoi(fields[5])
+	if err != nil {
+		return nil, fmt.Errorf("parsing curl trace status code %q: %w", fields[5], err)
+	}
+
+	dnsLookup, connect, appConnect, startTransfer, total := seconds[0], seconds[1], seconds[2], seconds[3], seconds[4]
+
+	// time_appconnect is 0 for plain HTTP (no TLS handshake took place), so
+	// don't attribute a bogus negative TLS duration in that case.
+	tlsHandshake := 0.0
+	serverStart := connect
+	if appConnect > 0 {
+		tlsHandshake = appConnect - connect
+		serverStart = appConnect
+	}
+
+	return &Trace{
+		DNSLookupMs:        dnsLookup * 1000,
+		TCPConnectMs:       (connect - dnsLookup) * 1000,
+		TLSHandshakeMs:     tlsHandshake * 1000,
+		ServerProcessingMs: (startTransfer - serverStart) * 1000,
+		TotalMs:            total * 1000,
+		StatusCode:         statusCode,
+		RemoteAddr:         fields[6],
+	}, nil
+}
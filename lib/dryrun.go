@@ -0,0 +1,15 @@
+package httpcurl
+
+import "github.com/roketid/http-curl/shellescape"
+
+// BuildCommand validates options the same way sanitizeInput does and
+// reconstructs the equivalent, shell-escaped curl command line, without
+// executing anything. It backs the /curl?dryrun=true preview.
+func BuildCommand(options CurlOption) (string, error) {
+	args, err := sanitizeInput(options)
+	if err != nil {
+		return "", err
+	}
+
+	return "curl " + shellescape.QuoteArgs(args), nil
+}
@@ -0,0 +1,42 @@
+package httpcurl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCommand_ShellEscapesArguments(t *testing.T) {
+	options := CurlOption{
+		"-X":         CurlValue{"POST"},
+		"-d":         CurlValue{`{"name":"o'brien"}`},
+		"--location": CurlValue{"https://example.com/create"},
+	}
+
+	command, err := BuildCommand(options)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(command, "curl "))
+	assert.Contains(t, command, "'https://example.com/create'")
+	assert.Contains(t, command, `'{"name":"o'\''brien"}'`)
+}
+
+func TestBuildCommand_UnauthorizedOption(t *testing.T) {
+	options := CurlOption{"--output": CurlValue{"/etc/passwd"}}
+
+	_, err := BuildCommand(options)
+	assert.Error(t, err)
+}
+
+func TestBuildCommand_BooleanFlag(t *testing.T) {
+	options := CurlOption{
+		"-k":         CurlValue{""},
+		"--location": CurlValue{"https://example.com"},
+	}
+
+	command, err := BuildCommand(options)
+	require.NoError(t, err)
+	assert.Contains(t, command, "'-k'")
+}
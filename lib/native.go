@@ -0,0 +1,329 @@
+package httpcurl
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sharedTransport is reused across NativeBackend requests so repeat calls
+// can keep TCP/TLS connections alive instead of paying a fresh handshake
+// per request, the way the curl subprocess backend effectively does per
+// call.
+var sharedTransport = &http.Transport{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+	DialContext:         safeDialContext(DefaultHostPolicy()),
+}
+
+// NativeBackend runs requests in-process against net/http instead of
+// shelling out to curl. A nil policy falls back to DefaultHostPolicy();
+// tests override it to permit the loopback addresses httptest servers run
+// on.
+type NativeBackend struct {
+	transport *http.Transport
+	policy    *HostPolicy
+}
+
+// NewNativeBackend returns a NativeBackend backed by the package's shared,
+// keep-alive-enabled transport, using DefaultHostPolicy().
+func NewNativeBackend() *NativeBackend {
+	return NewNativeBackendWithPolicy(nil)
+}
+
+// NewNativeBackendWithPolicy is like NewNativeBackend but validates targets
+// against policy instead of DefaultHostPolicy() when policy is non-nil.
+func NewNativeBackendWithPolicy(policy *HostPolicy) *NativeBackend {
+	return &NativeBackend{transport: sharedTransport, policy: policy}
+}
+
+func (b *NativeBackend) policyOrDefault() *HostPolicy {
+	if b.policy != nil {
+		return b.policy
+	}
+	return DefaultHostPolicy()
+}
+
+// Execute implements Backend.
+func (b *NativeBackend) Execute(options CurlOption, timeout time.Duration) (string, error) {
+	result, _, err := b.ExecuteWithTrace(options, timeout)
+	return result, err
+}
+
+// ExecuteWithTrace implements TracingBackend, recording DNS/connect/TLS/
+// server-processing timings via httptrace.ClientTrace hooks.
+func (b *NativeBackend) ExecuteWithTrace(options CurlOption, timeout time.Duration) (string, *Trace, error) {
+	if _, err := sanitizeInput(options); err != nil {
+		return "", nil, err
+	}
+
+	req, insecure, proxyURL, err := buildRequest(options)
+	if err != nil {
+		return "", nil, err
+	}
+
+	policy := b.policyOrDefault()
+	if _, _, err := policy.ValidateTarget(req.Context(), req.URL.String()); err != nil {
+		return "", nil, fmt.Errorf("ssrf policy: %w", err)
+	}
+
+	client := &http.Client{
+		Transport: b.resolvedTransport(insecure, proxyURL),
+		Timeout:   timeout,
+	}
+
+	trace := &Trace{}
+	var dnsStart, connectStart, tlsStart time.Time
+	start := time.Now()
+
+	clientTrace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { trace.DNSLookupMs = msSince(dnsStart) },
+		ConnectStart:         func(string, string) { connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { trace.TCPConnectMs = msSince(connectStart) },
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { trace.TLSHandshakeMs = msSince(tlsStart) },
+		GotFirstResponseByte: func() { trace.ServerProcessingMs = msSince(start) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn != nil {
+				trace.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if isTimeoutErr(err) {
+			return "", nil, fmt.Errorf("request timed out after %s: %w", timeout, err)
+		}
+		return "", nil, fmt.Errorf("native request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	trace.TotalMs = msSince(start)
+	trace.StatusCode = resp.StatusCode
+	trace.ResponseHeaders = map[string][]string(resp.Header)
+
+	return string(body), trace, nil
+}
+
+// executeWithStatus implements statusBackend, letting ExecuteWithRetry
+// evaluate retry_on=5xx.
+func (b *NativeBackend) executeWithStatus(options CurlOption, timeout time.Duration) (string, int, error) {
+	result, trace, err := b.ExecuteWithTrace(options, timeout)
+	if err != nil {
+		return result, 0, err
+	}
+	return result, trace.StatusCode, nil
+}
+
+// ExecuteWithSession implements SessionBackend: it loads cookies from
+// cookieFile into a per-request jar, runs the request, and writes the
+// merged cookie set back. Persistence is done from resp.Cookies(), which
+// parses the raw Set-Cookie headers with their full attribute set, rather
+// than jar.Cookies(), which only ever returns Name/Value: going through the
+// jar would silently downgrade every cookie to a non-Secure, path-"/",
+// no-expiry one on every round-trip.
+func (b *NativeBackend) ExecuteWithSession(options CurlOption, timeout time.Duration, cookieFile string) (string, error) {
+	if _, err := sanitizeInput(options); err != nil {
+		return "", err
+	}
+
+	req, insecure, proxyURL, err := buildRequest(options)
+	if err != nil {
+		return "", err
+	}
+
+	policy := b.policyOrDefault()
+	if _, _, err := policy.ValidateTarget(req.Context(), req.URL.String()); err != nil {
+		return "", fmt.Errorf("ssrf policy: %w", err)
+	}
+
+	cookies, err := readNetscapeCookieFile(cookieFile)
+	if err != nil {
+		return "", err
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return "", fmt.Errorf("creating cookie jar: %w", err)
+	}
+	jar.SetCookies(req.URL, cookies)
+
+	client := &http.Client{
+		Transport: b.resolvedTransport(insecure, proxyURL),
+		Timeout:   timeout,
+		Jar:       jar,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if isTimeoutErr(err) {
+			return "", fmt.Errorf("request timed out after %s: %w", timeout, err)
+		}
+		return "", fmt.Errorf("native request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response body: %w", err)
+	}
+
+	merged := mergeCookies(cookies, resp.Cookies(), req.URL.Hostname())
+	if err := writeNetscapeCookieFile(cookieFile, req.URL.Hostname(), merged); err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+// mergeCookies folds updates (freshly parsed from this response's Set-Cookie
+// headers, full attributes intact) into existing (the session's
+// previously-stored cookies), keyed by domain/path/name the way a real
+// cookie jar would: an update replaces a same-keyed existing cookie, a
+// cookie whose Set-Cookie expired it (MaxAge<0 or Expires in the past) is
+// removed instead of kept around, and everything else carries over
+// untouched. defaultDomain fills in the comparison key for cookies with no
+// explicit Domain, matching writeNetscapeCookieFile's own fallback.
+func mergeCookies(existing, updates []*http.Cookie, defaultDomain string) []*http.Cookie {
+	type cookieKey struct{ domain, path, name string }
+	keyOf := func(c *http.Cookie) cookieKey {
+		domain := strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+		if domain == "" {
+			domain = defaultDomain
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		return cookieKey{domain, path, c.Name}
+	}
+
+	byKey := make(map[cookieKey]*http.Cookie, len(existing)+len(updates))
+	var order []cookieKey
+	for _, c := range existing {
+		k := keyOf(c)
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = c
+	}
+	for _, c := range updates {
+		k := keyOf(c)
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			delete(byKey, k)
+			continue
+		}
+		if _, ok := byKey[k]; !ok {
+			order = append(order, k)
+		}
+		byKey[k] = c
+	}
+
+	merged := make([]*http.Cookie, 0, len(byKey))
+	for _, k := range order {
+		if c, ok := byKey[k]; ok {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
+
+// resolvedTransport returns b.transport as-is, unless insecure, proxyURL or
+// a custom policy requires per-request overrides, in which case it clones
+// the shared transport so those overrides don't leak into other requests.
+func (b *NativeBackend) resolvedTransport(insecure bool, proxyURL *url.URL) *http.Transport {
+	if !insecure && proxyURL == nil && b.policy == nil {
+		return b.transport
+	}
+
+	transport := b.transport.Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // opt-in via -k
+	}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if b.policy != nil {
+		transport.DialContext = safeDialContext(b.policy)
+	}
+	return transport
+}
+
+// buildRequest translates a validated CurlOption map into an *http.Request
+// plus the flags that can't be expressed on the request itself (-k, -x).
+// The URL always comes from --location, since that's the only option this
+// API accepts for the request target.
+func buildRequest(options CurlOption) (req *http.Request, insecure bool, proxyURL *url.URL, err error) {
+	targetURL := firstValue(options, "--location")
+	if targetURL == "" {
+		return nil, false, nil, fmt.Errorf("missing --location: NativeBackend requires a target URL")
+	}
+
+	method := firstValue(options, "-X")
+	dataValues := append(append([]string{}, options["-d"]...), options["--data"]...)
+	body := strings.Join(dataValues, "&")
+	if method == "" {
+		method = "GET"
+		if body != "" {
+			method = "POST"
+		}
+	}
+
+	req, err = http.NewRequest(method, targetURL, strings.NewReader(body))
+	if err != nil {
+		return nil, false, nil, fmt.Errorf("building request: %w", err)
+	}
+
+	for _, header := range options["-H"] {
+		key, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+		req.Header.Add(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	if v, ok := options["-k"]; ok && len(v) > 0 {
+		insecure = true
+	}
+
+	if proxy := firstValue(options, "-x"); proxy != "" {
+		proxyURL, err = url.Parse(proxy)
+		if err != nil {
+			return nil, false, nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+	}
+
+	return req, insecure, proxyURL, nil
+}
+
+// firstValue returns the first value for option, or "" if it's absent.
+func firstValue(options CurlOption, option string) string {
+	values, ok := options[option]
+	if !ok || len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// isTimeoutErr reports whether err represents an http.Client timeout.
+func isTimeoutErr(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,147 @@
+package httpcurl
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures ExecuteWithRetry: how many extra attempts to make,
+// the exponential backoff schedule between them, and which kinds of
+// failures are worth retrying at all.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	RetryOn    RetryConditions
+}
+
+// RetryConditions selects which failure classes ExecuteWithRetry retries.
+// ServerErrors covers 5xx responses; Connect and Timeout cover the two
+// broad buckets an Execute error can fall into (see classifyError).
+type RetryConditions struct {
+	ServerErrors bool
+	Connect      bool
+	Timeout      bool
+}
+
+// ParseRetryOn parses the comma-separated retry_on query param (e.g.
+// "5xx,connect,timeout") into a RetryConditions. An empty string yields the
+// zero value (retry nothing).
+func ParseRetryOn(raw string) (RetryConditions, error) {
+	var conditions RetryConditions
+
+	for _, token := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(token) {
+		case "":
+			continue
+		case "5xx":
+			conditions.ServerErrors = true
+		case "connect":
+			conditions.Connect = true
+		case "timeout":
+			conditions.Timeout = true
+		default:
+			return RetryConditions{}, fmt.Errorf("unknown retry_on condition: %q", token)
+		}
+	}
+
+	return conditions, nil
+}
+
+// shouldRetryStatus reports whether statusCode alone warrants a retry.
+func (c RetryConditions) shouldRetryStatus(statusCode int) bool {
+	return c.ServerErrors && statusCode >= 500 && statusCode < 600
+}
+
+// shouldRetryError reports whether err warrants a retry, classifying it as
+// a timeout or a connect-class failure (see classifyError).
+func (c RetryConditions) shouldRetryError(err error) bool {
+	if err == nil {
+		return false
+	}
+	isTimeout, isConnect := classifyError(err)
+	return (c.Timeout && isTimeout) || (c.Connect && isConnect)
+}
+
+// classifyError buckets an Execute/ExecuteWithTrace error as a timeout (the
+// request ran out of time) or a connect-class failure (anything else:
+// DNS failure, connection refused, TLS handshake failure, a non-zero curl
+// exit code, ...). Both backends format timeouts the same way ("request
+// timed out after ...: %w"), which is what this keys off.
+func classifyError(err error) (isTimeout, isConnect bool) {
+	if strings.Contains(err.Error(), "timed out") {
+		return true, false
+	}
+	return false, true
+}
+
+// statusBackend is implemented by backends that can report the HTTP status
+// code they got back, which ExecuteWithRetry needs to evaluate retry_on=5xx.
+// Backends that don't implement it (there are none currently) are treated
+// as always returning status 0, so only retry_on=connect/timeout apply.
+type statusBackend interface {
+	executeWithStatus(options CurlOption, timeout time.Duration) (string, int, error)
+}
+
+// ExecuteWithRetry runs options against backend, retrying on the failures
+// policy.RetryOn selects, up to policy.MaxRetries extra attempts. Each
+// retry sleeps min(backoff*2^attempt + jitter, maxBackoff) first. The
+// overall time spent (all attempts plus sleeps) is bounded by timeout: each
+// attempt gets whatever of it remains. It returns the last attempt's
+// result/error and the number of attempts made.
+func ExecuteWithRetry(backend Backend, options CurlOption, timeout time.Duration, policy RetryPolicy) (result string, attempts int, err error) {
+	return executeWithRetry(backend, options, timeout, policy, time.Sleep)
+}
+
+func executeWithRetry(backend Backend, options CurlOption, timeout time.Duration, policy RetryPolicy, sleep func(time.Duration)) (result string, attempts int, err error) {
+	deadline := time.Now().Add(timeout)
+
+	for attempt := 0; ; attempt++ {
+		attempts = attempt + 1
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		statusCode := 0
+		if sb, ok := backend.(statusBackend); ok {
+			result, statusCode, err = sb.executeWithStatus(options, remaining)
+		} else {
+			result, err = backend.Execute(options, remaining)
+		}
+
+		retry := policy.RetryOn.shouldRetryError(err) || (err == nil && policy.RetryOn.shouldRetryStatus(statusCode))
+		if !retry || attempt >= policy.MaxRetries {
+			break
+		}
+
+		sleep(retryBackoff(policy, attempt))
+	}
+
+	return result, attempts, err
+}
+
+// retryBackoff computes min(backoff*2^attempt + jitter, maxBackoff), where
+// jitter is a random duration in [0, backoff) so a burst of simultaneously
+// retrying callers doesn't stay in lockstep.
+func retryBackoff(policy RetryPolicy, attempt int) time.Duration {
+	scaled := float64(policy.Backoff) * math.Pow(2, float64(attempt))
+
+	backoff := policy.MaxBackoff
+	if scaled < float64(policy.MaxBackoff) {
+		backoff = time.Duration(scaled)
+	}
+
+	if policy.Backoff > 0 {
+		backoff += time.Duration(rand.Int63n(int64(policy.Backoff)))
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+
+	return backoff
+}
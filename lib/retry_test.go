@@ -0,0 +1,141 @@
+package httpcurl
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryOn(t *testing.T) {
+	conditions, err := ParseRetryOn("5xx,connect,timeout")
+	require.NoError(t, err)
+	assert.Equal(t, RetryConditions{ServerErrors: true, Connect: true, Timeout: true}, conditions)
+
+	conditions, err = ParseRetryOn("")
+	require.NoError(t, err)
+	assert.Equal(t, RetryConditions{}, conditions)
+
+	_, err = ParseRetryOn("bogus")
+	assert.Error(t, err)
+}
+
+func TestRetryBackoff_ExponentialAndCapped(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	// attempt 0: base 100ms, plus jitter in [0, 100ms)
+	d := retryBackoff(policy, 0)
+	assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+	assert.Less(t, d, 200*time.Millisecond)
+
+	// attempt 5: base would be 3200ms, capped at MaxBackoff
+	d = retryBackoff(policy, 5)
+	assert.Equal(t, time.Second, d)
+}
+
+func TestExecuteWithRetry_RetriesOn503ThenSucceeds(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	options := CurlOption{"--location": CurlValue{server.URL}}
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, RetryOn: RetryConditions{ServerErrors: true}}
+
+	var slept []time.Duration
+	fakeSleep := func(d time.Duration) { slept = append(slept, d) }
+
+	result, attempts, err := executeWithRetry(backend, options, time.Second, policy, fakeSleep)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 3, requestCount)
+	assert.Len(t, slept, 2)
+}
+
+func TestExecuteWithRetry_ExhaustsRetriesAndReturnsLastResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("down"))
+	}))
+	defer server.Close()
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	options := CurlOption{"--location": CurlValue{server.URL}}
+	policy := RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryOn: RetryConditions{ServerErrors: true}}
+
+	result, attempts, err := executeWithRetry(backend, options, time.Second, policy, func(time.Duration) {})
+	require.NoError(t, err)
+	assert.Equal(t, "down", result)
+	assert.Equal(t, 3, attempts) // initial attempt + 2 retries
+}
+
+func TestExecuteWithRetry_DoesNotRetryWhenConditionNotSelected(t *testing.T) {
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	options := CurlOption{"--location": CurlValue{server.URL}}
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond} // RetryOn left zero-value
+
+	_, attempts, err := executeWithRetry(backend, options, time.Second, policy, func(time.Duration) {})
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 1, requestCount)
+}
+
+func TestExecuteWithRetry_RetriesOnConnectError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	addr := server.Listener.Addr().String()
+	server.Close() // nothing is listening here anymore
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	options := CurlOption{"--location": CurlValue{"http://" + addr}}
+	policy := RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryOn: RetryConditions{Connect: true}}
+
+	_, attempts, err := executeWithRetry(backend, options, time.Second, policy, func(time.Duration) {})
+	assert.Error(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestClassifyError_Timeout(t *testing.T) {
+	isTimeout, isConnect := classifyError(errors.New("request timed out after 5s: context deadline exceeded"))
+	assert.True(t, isTimeout)
+	assert.False(t, isConnect)
+
+	isTimeout, isConnect = classifyError(errors.New("native request failed: connection refused"))
+	assert.False(t, isTimeout)
+	assert.True(t, isConnect)
+}
+
+func TestCurlBinaryBackend_ExecuteWithStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("short and stout"))
+	}))
+	defer server.Close()
+
+	backend := &CurlBinaryBackend{policy: testLoopbackPolicy(t)}
+	body, statusCode, err := backend.executeWithStatus(CurlOption{"--location": CurlValue{server.URL}}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "short and stout", body)
+	assert.Equal(t, http.StatusTeapot, statusCode)
+}
@@ -0,0 +1,138 @@
+package httpcurl
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Session tracks the cookie-jar file backing one session_id, so repeated
+// /curl calls (e.g. a login POST followed by requests that depend on its
+// cookies) can share state across both backends.
+type Session struct {
+	ID         string
+	CookieFile string
+
+	expiresAt time.Time
+}
+
+// SessionStore is a bounded, TTL-expiring cache of Sessions, keyed by
+// caller-supplied session_id. It's bounded by maxEntries (evicting the
+// least-recently-used session when full) rather than growing unbounded
+// with however many distinct IDs callers send.
+type SessionStore struct {
+	mu         sync.Mutex
+	dir        string
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List // most-recently-used session at the front
+	elements   map[string]*list.Element
+}
+
+// NewSessionStore creates the cookie-file directory (if needed) and returns
+// a store that keeps at most maxEntries sessions alive for ttl since their
+// last use.
+func NewSessionStore(dir string, maxEntries int, ttl time.Duration) (*SessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating session directory: %w", err)
+	}
+
+	return &SessionStore{
+		dir:        dir,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		elements:   make(map[string]*list.Element),
+	}, nil
+}
+
+// GetOrCreate returns the Session for id, creating one if it doesn't exist
+// or has expired. Touching a session refreshes both its TTL and its
+// position in the LRU order; once the store is at capacity, creating a new
+// session evicts the least-recently-used one.
+func (s *SessionStore) GetOrCreate(id string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.elements[id]; ok {
+		session := el.Value.(*Session)
+		if time.Now().Before(session.expiresAt) {
+			session.expiresAt = time.Now().Add(s.ttl)
+			s.order.MoveToFront(el)
+			return session
+		}
+		s.removeElement(el)
+	}
+
+	session := &Session{
+		ID:         id,
+		CookieFile: filepath.Join(s.dir, hashSessionID(id)+".cookies"),
+		expiresAt:  time.Now().Add(s.ttl),
+	}
+	s.elements[id] = s.order.PushFront(session)
+	s.evictIfNeeded()
+
+	return session
+}
+
+// Delete removes id's session and its cookie file, if any.
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.elements[id]; ok {
+		s.removeElement(el)
+	}
+}
+
+// Cookies returns the cookies currently stored for id. ok is false if
+// there's no live (unexpired) session with that ID.
+func (s *SessionStore) Cookies(id string) (cookies []*http.Cookie, ok bool, err error) {
+	s.mu.Lock()
+	el, exists := s.elements[id]
+	if !exists || !time.Now().Before(el.Value.(*Session).expiresAt) {
+		s.mu.Unlock()
+		return nil, false, nil
+	}
+	cookieFile := el.Value.(*Session).CookieFile
+	s.mu.Unlock()
+
+	cookies, err = readNetscapeCookieFile(cookieFile)
+	if err != nil {
+		return nil, true, err
+	}
+	return cookies, true, nil
+}
+
+func (s *SessionStore) evictIfNeeded() {
+	for s.order.Len() > s.maxEntries {
+		oldest := s.order.Back()
+		if oldest == nil {
+			return
+		}
+		s.removeElement(oldest)
+	}
+}
+
+// removeElement drops el from both the order list and the index, and
+// deletes its cookie file. Callers must hold s.mu.
+func (s *SessionStore) removeElement(el *list.Element) {
+	session := el.Value.(*Session)
+	delete(s.elements, session.ID)
+	s.order.Remove(el)
+	_ = os.Remove(session.CookieFile)
+}
+
+// hashSessionID derives a filesystem-safe cookie-file name from a
+// caller-supplied session ID, so an ID containing "../" or other path
+// metacharacters can't be used to read or write outside dir.
+func hashSessionID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,156 @@
+package httpcurl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore_GetOrCreate_ReusesSameSession(t *testing.T) {
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+
+	first := store.GetOrCreate("alice")
+	second := store.GetOrCreate("alice")
+
+	assert.Equal(t, first.CookieFile, second.CookieFile)
+}
+
+func TestSessionStore_GetOrCreate_EvictsLeastRecentlyUsed(t *testing.T) {
+	store, err := NewSessionStore(t.TempDir(), 2, time.Minute)
+	require.NoError(t, err)
+
+	first := store.GetOrCreate("a")
+	store.GetOrCreate("b")
+	store.GetOrCreate("c") // evicts "a", the least recently used
+
+	_, ok, err := store.Cookies("a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, statErr := os.Stat(first.CookieFile)
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+func TestSessionStore_Delete_RemovesCookieFile(t *testing.T) {
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+
+	session := store.GetOrCreate("alice")
+	require.NoError(t, writeNetscapeCookieFile(session.CookieFile, "example.com", nil))
+
+	store.Delete("alice")
+
+	_, ok, err := store.Cookies("alice")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSessionStore_Cookies_UnknownSession(t *testing.T) {
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+
+	_, ok, err := store.Cookies("nope")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNativeBackend_ExecuteWithSession_PersistsCookiesAcrossRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/set" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("authenticated as " + cookie.Value))
+	}))
+	defer server.Close()
+
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+	session := store.GetOrCreate("user-1")
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+
+	_, err = backend.ExecuteWithSession(CurlOption{"--location": CurlValue{server.URL + "/set"}}, 5*time.Second, session.CookieFile)
+	require.NoError(t, err)
+
+	output, err := backend.ExecuteWithSession(CurlOption{"--location": CurlValue{server.URL + "/whoami"}}, 5*time.Second, session.CookieFile)
+	require.NoError(t, err)
+	assert.Equal(t, "authenticated as abc123", output)
+
+	cookies, ok, err := store.Cookies("user-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "session", cookies[0].Name)
+}
+
+func TestNativeBackend_ExecuteWithSession_PreservesCookieAttributes(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{
+			Name:    "session",
+			Value:   "abc123",
+			Path:    "/account",
+			Secure:  true,
+			Expires: expires,
+		})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+	session := store.GetOrCreate("user-1")
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	_, err = backend.ExecuteWithSession(CurlOption{"--location": CurlValue{server.URL}}, 5*time.Second, session.CookieFile)
+	require.NoError(t, err)
+
+	// A cookiejar.Jar.Cookies() round-trip would have flattened this down to
+	// a non-Secure, path-"/", no-expiry cookie; reading the persisted file
+	// directly confirms the full Set-Cookie attribute set survived instead.
+	cookies, ok, err := store.Cookies("user-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, cookies, 1)
+
+	cookie := cookies[0]
+	assert.Equal(t, "abc123", cookie.Value)
+	assert.Equal(t, "/account", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.Equal(t, expires.Unix(), cookie.Expires.Unix())
+}
+
+func TestCurlBinaryBackend_ExecuteWithSession_PinsCookieFileFlags(t *testing.T) {
+	// CurlBinaryBackend delegates cookie persistence entirely to the curl
+	// subprocess's own -b/-c handling; this just checks the call doesn't
+	// error building its arguments for a locally-reachable target.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	store, err := NewSessionStore(t.TempDir(), 10, time.Minute)
+	require.NoError(t, err)
+	session := store.GetOrCreate("user-1")
+
+	backend := &CurlBinaryBackend{policy: testLoopbackPolicy(t)}
+	output, err := backend.ExecuteWithSession(CurlOption{"--location": CurlValue{server.URL}}, 5*time.Second, session.CookieFile)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", output)
+}
@@ -0,0 +1,281 @@
+package httpcurl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"syscall"
+)
+
+// defaultDenyCIDRs blocks the address ranges a server-side HTTP client
+// should never be allowed to reach on an operator's behalf: loopback,
+// RFC1918 private space, link-local (which also covers the 169.254.169.254
+// cloud metadata endpoint), and their IPv6 equivalents.
+var defaultDenyCIDRs = []string{
+	"0.0.0.0/8", // "this" network; on Linux, connecting to it reaches loopback
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"169.254.0.0/16",
+	"::/128", // the IPv6 unspecified address; also reaches loopback on connect
+	"::1/128",
+	"fc00::/7",  // unique local addresses
+	"fe80::/10", // link-local
+}
+
+// defaultDenyHosts blocks well-known cloud metadata hostnames that resolve
+// outside the ranges above.
+var defaultDenyHosts = []string{
+	"metadata.google.internal",
+}
+
+// HostPolicy decides whether a resolved IP address is allowed to be dialed.
+// Allow entries take precedence over deny entries, so an operator can carve
+// out an exception inside an otherwise-blocked range.
+type HostPolicy struct {
+	AllowCIDRs []*net.IPNet
+	DenyCIDRs  []*net.IPNet
+
+	// AllowHosts and DenyHosts are glob patterns (as in path.Match) matched
+	// against the request's hostname, checked before DNS resolution.
+	AllowHosts []string
+	DenyHosts  []string
+
+	// resolve looks up host's IP addresses. It's a field (rather than a
+	// direct net.DefaultResolver call) so tests can substitute a fake
+	// resolver to exercise DNS-rebinding scenarios deterministically.
+	resolve func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+// DefaultHostPolicy returns the built-in policy: deny private/loopback/
+// link-local ranges and known metadata hostnames, with no allowlist.
+func DefaultHostPolicy() *HostPolicy {
+	policy, err := NewHostPolicy(nil, defaultDenyCIDRs, nil, defaultDenyHosts)
+	if err != nil {
+		// defaultDenyCIDRs is a constant, known-valid list.
+		panic(err)
+	}
+	return policy
+}
+
+// NewConfiguredHostPolicy builds a HostPolicy from operator-supplied
+// allow/deny CIDRs and hostname globs, layered on top of the built-in deny
+// list (private/loopback/link-local ranges and known metadata hostnames) so
+// config can only add exceptions or extra restrictions, never silently drop
+// the defaults.
+func NewConfiguredHostPolicy(allowCIDRs, denyCIDRs, allowHosts, denyHosts []string) (*HostPolicy, error) {
+	return NewHostPolicy(
+		allowCIDRs,
+		append(append([]string{}, defaultDenyCIDRs...), denyCIDRs...),
+		allowHosts,
+		append(append([]string{}, defaultDenyHosts...), denyHosts...),
+	)
+}
+
+// NewHostPolicy parses the given CIDR strings and builds a HostPolicy from
+// them plus the given hostname glob lists.
+func NewHostPolicy(allowCIDRs, denyCIDRs, allowHosts, denyHosts []string) (*HostPolicy, error) {
+	parsedAllow, err := parseCIDRs(allowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	parsedDeny, err := parseCIDRs(denyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HostPolicy{
+		AllowCIDRs: parsedAllow,
+		DenyCIDRs:  parsedDeny,
+		AllowHosts: allowHosts,
+		DenyHosts:  denyHosts,
+		resolve:    lookupIPs,
+	}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func lookupIPs(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
+
+func matchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, host); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsIP reports whether ip is permitted: explicit allow CIDRs win,
+// explicit deny CIDRs lose, and anything else is permitted.
+func (p *HostPolicy) allowsIP(ip net.IP) bool {
+	for _, n := range p.AllowCIDRs {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	for _, n := range p.DenyCIDRs {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveAllowed resolves host and returns the subset of its addresses
+// permitted by the policy. A hostname matching AllowHosts bypasses the IP
+// checks entirely (an explicit operator override); one matching DenyHosts
+// is rejected before DNS is even consulted.
+func (p *HostPolicy) ResolveAllowed(ctx context.Context, host string) ([]net.IP, error) {
+	hostAllowed := matchesAny(p.AllowHosts, host)
+	if !hostAllowed && matchesAny(p.DenyHosts, host) {
+		return nil, fmt.Errorf("ssrf policy: host %q is denied", host)
+	}
+
+	var ips []net.IP
+	if literal := net.ParseIP(host); literal != nil {
+		ips = []net.IP{literal}
+	} else {
+		resolved, err := p.resolve(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("resolving host %q: %w", host, err)
+		}
+		ips = resolved
+	}
+
+	allowed := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if hostAllowed || p.allowsIP(ip) {
+			allowed = append(allowed, ip)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("ssrf policy: host %q has no permitted addresses (resolved %v)", host, ips)
+	}
+
+	return allowed, nil
+}
+
+// ValidateTarget parses rawURL's host, resolves it, and returns its
+// policy-permitted IP addresses. It's the shared SSRF gate used by both
+// backends before they connect anywhere.
+func (p *HostPolicy) ValidateTarget(ctx context.Context, rawURL string) (host string, ips []net.IP, err error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing target URL: %w", err)
+	}
+	host = parsed.Hostname()
+	if host == "" {
+		return "", nil, fmt.Errorf("target URL %q has no host", rawURL)
+	}
+
+	ips, err = p.ResolveAllowed(ctx, host)
+	if err != nil {
+		return "", nil, err
+	}
+	return host, ips, nil
+}
+
+// curlResolvePinArgs builds the curl --resolve argument pinning rawURL's
+// host to one of its policy-permitted ips, so curl can't re-resolve the
+// hostname (and land on a different, unvalidated address) between our
+// check and its own connect. No pin is needed if the URL already targets a
+// literal IP.
+func curlResolvePinArgs(rawURL string, ips []net.IP) ([]string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing target URL: %w", err)
+	}
+	host := parsed.Hostname()
+	if net.ParseIP(host) != nil || len(ips) == 0 {
+		return nil, nil
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = "80"
+		if parsed.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return []string{"--resolve", fmt.Sprintf("%s:%s:%s", host, port, ips[0].String())}, nil
+}
+
+// resolveAndPin validates rawURL against policy and returns the extra curl
+// arguments needed to pin the connection to the address that was checked.
+func resolveAndPin(ctx context.Context, policy *HostPolicy, rawURL string) ([]string, error) {
+	_, ips, err := policy.ValidateTarget(ctx, rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ssrf policy: %w", err)
+	}
+	return curlResolvePinArgs(rawURL, ips)
+}
+
+// safeDialContext returns a DialContext function that resolves the target
+// host itself (rather than delegating to net.Dialer's built-in resolution),
+// checks every candidate address against policy, and dials the first
+// permitted one directly by IP. Its Control callback re-validates that same
+// address immediately before connect as a last line of defense against
+// DNS-rebinding (the resolver returning a permitted address the first time
+// and a blocked one on a second lookup mid-request).
+func safeDialContext(policy *HostPolicy) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Control: func(_, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !policy.allowsIP(ip) {
+				return fmt.Errorf("ssrf policy: refusing to connect to %s", address)
+			}
+			return nil
+		},
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := policy.ResolveAllowed(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
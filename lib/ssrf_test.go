@@ -0,0 +1,174 @@
+package httpcurl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLoopbackPolicy allows 127.0.0.0/8 and ::1/128 on top of the default
+// deny list, so tests can exercise backends against local httptest servers
+// without tripping the SSRF guard the rest of this file verifies.
+func testLoopbackPolicy(t *testing.T) *HostPolicy {
+	t.Helper()
+	policy, err := NewHostPolicy([]string{"127.0.0.0/8", "::1/128"}, defaultDenyCIDRs, nil, nil)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestHostPolicy_AllowsIP_DefaultDeniesPrivateRanges(t *testing.T) {
+	policy := DefaultHostPolicy()
+
+	denied := []string{
+		"127.0.0.1",       // loopback
+		"0.0.0.0",         // reaches loopback on connect on Linux
+		"10.0.0.1",        // RFC1918
+		"172.16.0.1",      // RFC1918
+		"192.168.1.1",     // RFC1918
+		"169.254.169.254", // cloud metadata / link-local
+		"::1",             // IPv6 loopback
+		"::",              // IPv6 unspecified address; also reaches loopback
+		"fd00::1",         // IPv6 unique local
+		"fe80::1",         // IPv6 link-local
+	}
+	for _, ip := range denied {
+		assert.False(t, policy.allowsIP(net.ParseIP(ip)), "expected %s to be denied", ip)
+	}
+
+	allowed := []string{"8.8.8.8", "2001:4860:4860::8888"}
+	for _, ip := range allowed {
+		assert.True(t, policy.allowsIP(net.ParseIP(ip)), "expected %s to be allowed", ip)
+	}
+}
+
+func TestHostPolicy_AllowCIDR_OverridesDeny(t *testing.T) {
+	policy, err := NewHostPolicy([]string{"10.0.0.0/24"}, defaultDenyCIDRs, nil, nil)
+	require.NoError(t, err)
+
+	assert.True(t, policy.allowsIP(net.ParseIP("10.0.0.5")), "explicit allow CIDR should override the default deny range")
+	assert.False(t, policy.allowsIP(net.ParseIP("10.0.1.5")), "addresses outside the allow CIDR stay denied")
+}
+
+func TestHostPolicy_DenyHost_RejectsBeforeResolution(t *testing.T) {
+	policy := DefaultHostPolicy()
+
+	_, err := policy.ResolveAllowed(context.Background(), "metadata.google.internal")
+	assert.Error(t, err)
+}
+
+func TestHostPolicy_AllowHost_BypassesIPCheck(t *testing.T) {
+	policy, err := NewHostPolicy(nil, defaultDenyCIDRs, []string{"internal.example.test"}, nil)
+	require.NoError(t, err)
+	policy.resolve = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("10.0.0.1")}, nil
+	}
+
+	ips, err := policy.ResolveAllowed(context.Background(), "internal.example.test")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", ips[0].String())
+}
+
+func TestHostPolicy_ResolveAllowed_AllAddressesBlocked(t *testing.T) {
+	policy := DefaultHostPolicy()
+	policy.resolve = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("169.254.169.254")}, nil
+	}
+
+	_, err := policy.ResolveAllowed(context.Background(), "rebinding.example.test")
+	assert.Error(t, err)
+}
+
+// TestHostPolicy_DNSRebinding simulates the classic rebinding attack: the
+// resolver returns a permitted address the first time (when the attacker
+// expects validation to happen) and a blocked, internal address on a
+// second lookup (when they expect the real connection to happen). Because
+// safeDialContext resolves once and dials the IP it validated rather than
+// handing the hostname to the dialer, the second, malicious answer is never
+// used to connect.
+func TestHostPolicy_DNSRebinding_SecondLookupIsAlsoValidated(t *testing.T) {
+	lookups := 0
+	policy := DefaultHostPolicy()
+	policy.resolve = func(ctx context.Context, host string) ([]net.IP, error) {
+		lookups++
+		if lookups == 1 {
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		}
+		return []net.IP{net.ParseIP("169.254.169.254")}, nil
+	}
+
+	firstIPs, err := policy.ResolveAllowed(context.Background(), "rebinding.example.test")
+	require.NoError(t, err)
+	assert.Equal(t, "93.184.216.34", firstIPs[0].String())
+
+	_, err = policy.ResolveAllowed(context.Background(), "rebinding.example.test")
+	assert.Error(t, err, "a second, rebound lookup landing on a blocked address must be rejected too")
+}
+
+func TestSafeDialContext_DialsValidatedIPAndRejectsBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	serverAddr := server.Listener.Addr().(*net.TCPAddr)
+
+	allowPolicy, err := NewHostPolicy([]string{serverAddr.IP.String() + "/32"}, defaultDenyCIDRs, nil, nil)
+	require.NoError(t, err)
+	allowPolicy.resolve = func(ctx context.Context, host string) ([]net.IP, error) {
+		return []net.IP{serverAddr.IP}, nil
+	}
+
+	dial := safeDialContext(allowPolicy)
+	conn, err := dial(context.Background(), "tcp", net.JoinHostPort("example.test", fmt.Sprint(serverAddr.Port)))
+	require.NoError(t, err)
+	_ = conn.Close()
+
+	blockedDial := safeDialContext(DefaultHostPolicy())
+	_, err = blockedDial(context.Background(), "tcp", "127.0.0.1:80")
+	assert.Error(t, err)
+}
+
+func TestValidateTarget_BlocksPrivateTargetEndToEnd(t *testing.T) {
+	_, _, err := DefaultHostPolicy().ValidateTarget(context.Background(), "http://127.0.0.1:1234/")
+	assert.Error(t, err)
+}
+
+func TestNativeBackend_Execute_BlocksSSRFTarget(t *testing.T) {
+	options := CurlOption{"--location": CurlValue{"http://169.254.169.254/latest/meta-data/"}}
+
+	_, err := NewNativeBackend().Execute(options, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ssrf policy")
+}
+
+func TestHttpCurl_BlocksSSRFTarget(t *testing.T) {
+	options := CurlOption{"--location": CurlValue{"http://127.0.0.1:1/"}}
+
+	_, err := HttpCurl(options, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ssrf policy")
+}
+
+func TestCurlBinaryBackend_Execute_RejectsRedirectToBlockedTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	// The redirecting server itself is an allowed loopback target; only the
+	// hop it redirects to is blocked. If curl followed the redirect (its
+	// default behavior for --location), it would connect there with no SSRF
+	// check at all.
+	options := CurlOption{"--location": CurlValue{server.URL}}
+
+	backend := &CurlBinaryBackend{policy: testLoopbackPolicy(t)}
+	_, err := backend.Execute(options, 5*time.Second)
+	assert.Error(t, err)
+}
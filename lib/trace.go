@@ -0,0 +1,34 @@
+package httpcurl
+
+import "time"
+
+// Trace carries timing and response metadata for a single request,
+// populated by whichever Backend ran it. Durations are expressed in
+// milliseconds since that's the unit curl's -w write-out and most
+// dashboards use.
+type Trace struct {
+	DNSLookupMs        float64             `json:"dns_lookup_ms"`
+	TCPConnectMs       float64             `json:"tcp_connect_ms"`
+	TLSHandshakeMs     float64             `json:"tls_handshake_ms"`
+	ServerProcessingMs float64             `json:"server_processing_ms"`
+	TotalMs            float64             `json:"total_ms"`
+	StatusCode         int                 `json:"status_code"`
+	ResponseHeaders    map[string][]string `json:"response_headers"`
+	RemoteAddr         string              `json:"remote_addr"`
+}
+
+// TracingBackend is implemented by backends that can report Trace metadata
+// alongside the response body. Both CurlBinaryBackend and NativeBackend
+// implement it; Execute remains the simple entry point for callers that
+// don't need timing data.
+type TracingBackend interface {
+	Backend
+	ExecuteWithTrace(options CurlOption, timeout time.Duration) (string, *Trace, error)
+}
+
+func msSince(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(time.Since(t).Microseconds()) / 1000.0
+}
@@ -0,0 +1,76 @@
+package httpcurl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeBackend_ExecuteWithTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	options := CurlOption{"--location": CurlValue{server.URL}}
+
+	backend := &NativeBackend{transport: sharedTransport, policy: testLoopbackPolicy(t)}
+	output, trace, err := backend.ExecuteWithTrace(options, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", output)
+	require.NotNil(t, trace)
+	assert.Equal(t, http.StatusOK, trace.StatusCode)
+	assert.NotEmpty(t, trace.RemoteAddr)
+	assert.GreaterOrEqual(t, trace.TotalMs, 0.0)
+	assert.Contains(t, trace.ResponseHeaders["X-Test"], "yes")
+}
+
+func TestCurlBinaryBackend_ExecuteWithTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	options := CurlOption{"--location": CurlValue{server.URL}}
+
+	output, trace, err := (&CurlBinaryBackend{policy: testLoopbackPolicy(t)}).ExecuteWithTrace(options, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", output)
+	require.NotNil(t, trace)
+	assert.Equal(t, http.StatusOK, trace.StatusCode)
+	assert.NotEmpty(t, trace.RemoteAddr)
+	assert.GreaterOrEqual(t, trace.TotalMs, 0.0)
+	assert.Contains(t, trace.ResponseHeaders["X-Test"], "yes")
+}
+
+func TestParseTraceLine(t *testing.T) {
+	trace, err := parseTraceLine("0.001000|0.002000|0.003000|0.005000|0.006000|200|127.0.0.1:443")
+	require.NoError(t, err)
+	assert.Equal(t, 200, trace.StatusCode)
+	assert.Equal(t, "127.0.0.1:443", trace.RemoteAddr)
+	assert.InDelta(t, 1.0, trace.DNSLookupMs, 0.001)
+	assert.InDelta(t, 1.0, trace.TCPConnectMs, 0.001)
+	assert.InDelta(t, 1.0, trace.TLSHandshakeMs, 0.001)
+	assert.InDelta(t, 2.0, trace.ServerProcessingMs, 0.001)
+	assert.InDelta(t, 6.0, trace.TotalMs, 0.001)
+}
+
+func TestParseTraceLine_NoTLS(t *testing.T) {
+	trace, err := parseTraceLine("0.001000|0.002000|0.000000|0.005000|0.006000|200|127.0.0.1:80")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, trace.TLSHandshakeMs)
+	assert.InDelta(t, 3.0, trace.ServerProcessingMs, 0.001)
+}
+
+func TestParseTraceLine_InvalidFormat(t *testing.T) {
+	_, err := parseTraceLine("not-enough-fields")
+	assert.Error(t, err)
+}
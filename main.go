@@ -0,0 +1,382 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	httpcurl "github.com/roketid/http-curl/lib"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+
+	// defaultRPM is the per-key rate limit used when an API key entry in
+	// HTTPCURL_API_KEYS doesn't specify its own.
+	defaultRPM = 300
+
+	// defaultMaxConcurrent bounds the number of in-flight /curl requests,
+	// overridable via HTTPCURL_MAX_CONCURRENT.
+	defaultMaxConcurrent = 50
+
+	// defaultSessionTTL is how long a session's cookies survive without
+	// being touched, overridable via HTTPCURL_SESSION_TTL.
+	defaultSessionTTL = 30 * time.Minute
+
+	// defaultSessionMaxEntries bounds how many distinct sessions are kept
+	// alive at once, overridable via HTTPCURL_SESSION_MAX.
+	defaultSessionMaxEntries = 1000
+)
+
+// makeHandleCurl returns the /curl handler bound to policy, the SSRF
+// allow/deny rules backends validate request targets against. It accepts
+// either a JSON map of curl options or a raw curl command line (as
+// {"cmd": "curl -X POST ... https://..."}), runs it through httpcurl, and
+// returns the result as JSON (or plain text/base64 depending on the query
+// params below).
+//
+// Query params:
+//   - timeout: a duration string (e.g. "5s") bounding the curl subprocess
+//   - base64: when "true", base64-encode the result before returning it
+//   - plain: when "true", return the raw result as text/plain instead of JSON
+//   - backend: "curl" (default) or "native" - which httpcurl.Backend to run the request through
+//   - trace: when "true", wrap the result in {"result": ..., "trace": {...}} with DNS/connect/TLS/server timing
+//   - dryrun: when "true", return {"command": "curl ..."} (the shell-escaped
+//     equivalent curl invocation) without executing anything
+//   - retries: when set, retry up to that many times on the failures
+//     retry_on selects, sleeping retry_backoff*2^attempt (capped at
+//     retry_max_backoff, plus jitter) between attempts; the response becomes
+//     {"result": ..., "attempts": N}. retry_on is a comma-separated list of
+//     "5xx", "connect" and/or "timeout" (default: none, i.e. retries alone
+//     does nothing).
+//
+// A session_id query param or X-Session-Id header shares cookies (e.g. from
+// a login POST) across requests: see registerRoutes' /session/:id routes.
+func makeHandleCurl(policy *httpcurl.HostPolicy, sessions *httpcurl.SessionStore) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().Header.Get(echo.HeaderContentType) != echo.MIMEApplicationJSON {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Content-Type must be application/json"})
+		}
+
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON input: " + err.Error()})
+		}
+
+		var envelope struct {
+			Cmd string `json:"cmd"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON input: " + err.Error()})
+		}
+
+		var options httpcurl.CurlOption
+		if envelope.Cmd != "" {
+			options, err = httpcurl.ParseCommand(envelope.Cmd)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		} else if err := json.Unmarshal(body, &options); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid JSON input: " + err.Error()})
+		}
+
+		if c.QueryParam("dryrun") == "true" {
+			command, err := httpcurl.BuildCommand(options)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusOK, map[string]string{"command": command})
+		}
+
+		backend, err := httpcurl.ResolveBackend(c.QueryParam("backend"), policy)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+
+		timeout := defaultTimeout
+		if raw := c.QueryParam("timeout"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Error parsing timeout duration: " + err.Error()})
+			}
+			timeout = parsed
+		}
+
+		if c.QueryParam("trace") == "true" {
+			tracer, ok := backend.(httpcurl.TracingBackend)
+			if !ok {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "backend does not support tracing"})
+			}
+
+			result, trace, err := tracer.ExecuteWithTrace(options, timeout)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			}
+
+			if c.QueryParam("base64") == "true" {
+				result = base64.StdEncoding.EncodeToString([]byte(result))
+			}
+
+			return c.JSON(http.StatusOK, map[string]interface{}{"result": result, "trace": trace})
+		}
+
+		if c.QueryParam("retries") != "" {
+			retryPolicy, err := parseRetryPolicy(c)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+
+			result, attempts, err := httpcurl.ExecuteWithRetry(backend, options, timeout, retryPolicy)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "attempts": attempts})
+			}
+
+			if c.QueryParam("base64") == "true" {
+				result = base64.StdEncoding.EncodeToString([]byte(result))
+			}
+
+			return c.JSON(http.StatusOK, map[string]interface{}{"result": result, "attempts": attempts})
+		}
+
+		var result string
+		if sessionID := sessionIDFromRequest(c); sessionID != "" {
+			sessionBackend, ok := backend.(httpcurl.SessionBackend)
+			if !ok {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "backend does not support sessions"})
+			}
+			session := sessions.GetOrCreate(sessionID)
+			result, err = sessionBackend.ExecuteWithSession(options, timeout, session.CookieFile)
+		} else {
+			result, err = backend.Execute(options, timeout)
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+
+		if c.QueryParam("base64") == "true" {
+			result = base64.StdEncoding.EncodeToString([]byte(result))
+		}
+
+		if c.QueryParam("plain") == "true" {
+			c.Response().Header().Set(echo.HeaderContentType, "text/plain")
+			c.Response().WriteHeader(http.StatusOK)
+			_, err := c.Response().Write([]byte(result))
+			return err
+		}
+
+		return c.JSON(http.StatusOK, map[string]string{"result": result})
+	}
+}
+
+const (
+	// defaultRetryBackoff and defaultRetryMaxBackoff back retries=N when
+	// retry_backoff/retry_max_backoff aren't given.
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+)
+
+// parseRetryPolicy builds a RetryPolicy from the retries, retry_backoff,
+// retry_max_backoff and retry_on query params on c.
+func parseRetryPolicy(c echo.Context) (httpcurl.RetryPolicy, error) {
+	maxRetries, err := strconv.Atoi(c.QueryParam("retries"))
+	if err != nil {
+		return httpcurl.RetryPolicy{}, fmt.Errorf("invalid retries: %w", err)
+	}
+
+	backoff := defaultRetryBackoff
+	if raw := c.QueryParam("retry_backoff"); raw != "" {
+		backoff, err = time.ParseDuration(raw)
+		if err != nil {
+			return httpcurl.RetryPolicy{}, fmt.Errorf("invalid retry_backoff: %w", err)
+		}
+	}
+
+	maxBackoff := defaultRetryMaxBackoff
+	if raw := c.QueryParam("retry_max_backoff"); raw != "" {
+		maxBackoff, err = time.ParseDuration(raw)
+		if err != nil {
+			return httpcurl.RetryPolicy{}, fmt.Errorf("invalid retry_max_backoff: %w", err)
+		}
+	}
+
+	retryOn, err := httpcurl.ParseRetryOn(c.QueryParam("retry_on"))
+	if err != nil {
+		return httpcurl.RetryPolicy{}, err
+	}
+
+	return httpcurl.RetryPolicy{
+		MaxRetries: maxRetries,
+		Backoff:    backoff,
+		MaxBackoff: maxBackoff,
+		RetryOn:    retryOn,
+	}, nil
+}
+
+// sessionIDFromRequest returns the caller's session_id, checked first as a
+// query param and then as an X-Session-Id header, so either form works.
+func sessionIDFromRequest(c echo.Context) string {
+	if id := c.QueryParam("session_id"); id != "" {
+		return id
+	}
+	return c.Request().Header.Get("X-Session-Id")
+}
+
+// handleSessionCookies returns the cookies currently stored for the
+// session named by the :id path param, as JSON.
+func handleSessionCookies(sessions *httpcurl.SessionStore) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		cookies, ok, err := sessions.Cookies(c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+		if !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "no such session"})
+		}
+
+		result := make([]map[string]interface{}, len(cookies))
+		for i, cookie := range cookies {
+			result[i] = map[string]interface{}{
+				"name":    cookie.Name,
+				"value":   cookie.Value,
+				"domain":  cookie.Domain,
+				"path":    cookie.Path,
+				"secure":  cookie.Secure,
+				"expires": cookie.Expires,
+			}
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{"cookies": result})
+	}
+}
+
+// handleSessionDelete clears the session named by the :id path param.
+func handleSessionDelete(sessions *httpcurl.SessionStore) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sessions.Delete(c.Param("id"))
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// handleWaiting blocks for the given number of milliseconds before
+// responding. It exists so clients can exercise timeout handling end to
+// end without depending on a flaky third-party endpoint.
+func handleWaiting(c echo.Context) error {
+	milli, err := strconv.Atoi(c.Param("milli"))
+	if err != nil || milli < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid milliseconds"})
+	}
+
+	time.Sleep(time.Duration(milli) * time.Millisecond)
+
+	return c.String(http.StatusOK, "Ok")
+}
+
+// registerRoutes wires up the app's routes, including the auth, rate-limit
+// and concurrency-limit middleware guarding /curl. It's shared with the
+// test suite so tests exercise the exact same middleware chain as
+// production.
+func registerRoutes(e *echo.Echo, store *keyStore, maxConcurrent int, policy *httpcurl.HostPolicy, sessions *httpcurl.SessionStore) {
+	e.POST("/curl", makeHandleCurl(policy, sessions), requireAPIKey(store), rateLimitByKey(store), concurrencyLimit(maxConcurrent))
+	e.GET("/session/:id", handleSessionCookies(sessions), requireAPIKey(store))
+	e.DELETE("/session/:id", handleSessionDelete(sessions), requireAPIKey(store))
+	e.Any("/waiting/:milli", handleWaiting)
+}
+
+// loadHostPolicy builds the SSRF allow/deny policy from comma-separated
+// CIDR and hostname-glob lists in the environment. The built-in deny list
+// (private/loopback/link-local ranges, known metadata hostnames) always
+// applies; these env vars only add further exceptions or restrictions.
+func loadHostPolicy() (*httpcurl.HostPolicy, error) {
+	return httpcurl.NewConfiguredHostPolicy(
+		splitEnvList(os.Getenv("HTTPCURL_SSRF_ALLOW_CIDRS")),
+		splitEnvList(os.Getenv("HTTPCURL_SSRF_DENY_CIDRS")),
+		splitEnvList(os.Getenv("HTTPCURL_SSRF_ALLOW_HOSTS")),
+		splitEnvList(os.Getenv("HTTPCURL_SSRF_DENY_HOSTS")),
+	)
+}
+
+// loadSessionStore builds the cookie-jar session store, reading its
+// directory, TTL and capacity from the environment where overridden.
+func loadSessionStore() (*httpcurl.SessionStore, error) {
+	dir := os.Getenv("HTTPCURL_SESSION_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "httpcurl-sessions")
+	}
+
+	ttl := defaultSessionTTL
+	if raw := os.Getenv("HTTPCURL_SESSION_TTL"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTPCURL_SESSION_TTL: %w", err)
+		}
+		ttl = parsed
+	}
+
+	maxEntries := defaultSessionMaxEntries
+	if raw := os.Getenv("HTTPCURL_SESSION_MAX"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HTTPCURL_SESSION_MAX: %w", err)
+		}
+		maxEntries = parsed
+	}
+
+	return httpcurl.NewSessionStore(dir, maxEntries, ttl)
+}
+
+func splitEnvList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(raw, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func main() {
+	e := echo.New()
+	e.HideBanner = true
+
+	apiKeys, err := loadAPIKeys(os.Getenv("HTTPCURL_API_KEYS"), defaultRPM)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	store := newKeyStore(apiKeys)
+
+	hostPolicy, err := loadHostPolicy()
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	maxConcurrent := defaultMaxConcurrent
+	if raw := os.Getenv("HTTPCURL_MAX_CONCURRENT"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			e.Logger.Fatalf("invalid HTTPCURL_MAX_CONCURRENT: %v", err)
+		}
+		maxConcurrent = parsed
+	}
+
+	sessions, err := loadSessionStore()
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+
+	registerRoutes(e, store, maxConcurrent, hostPolicy, sessions)
+
+	e.Logger.Fatal(e.Start(":8080"))
+}
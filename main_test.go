@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -13,26 +13,39 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	httpcurl "github.com/roketid/http-curl/lib"
 )
 
+// testAPIKey is the key tests authenticate with; it's given a generous rate
+// limit so functional tests don't trip the limiter incidentally.
+const testAPIKey = "test-key"
+
 func setupTestServer() *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
 
-	// Add the same routes as main
-	e.POST("/curl", handleCurl)
-	e.Any("/waiting/:milli", func(c echo.Context) error {
-		milliStr := c.Param("milli")
-		milli, err := strconv.Atoi(milliStr)
-		if err != nil || milli < 0 {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid milliseconds"})
-		}
+	store := newKeyStore([]APIKey{{Key: testAPIKey, Label: "test", RPM: 10000}})
+
+	// Tests target httptest's loopback servers, which the default SSRF
+	// policy blocks; allow loopback here rather than loosen it in
+	// production.
+	policy, err := httpcurl.NewConfiguredHostPolicy([]string{"127.0.0.0/8", "::1/128"}, nil, nil, nil)
+	if err != nil {
+		panic(err)
+	}
 
-		// Convert milliseconds to duration
-		time.Sleep(time.Duration(milli) * time.Millisecond)
+	sessionDir, err := os.MkdirTemp("", "httpcurl-test-session-*")
+	if err != nil {
+		panic(err)
+	}
+
+	sessions, err := httpcurl.NewSessionStore(sessionDir, defaultSessionMaxEntries, defaultSessionTTL)
+	if err != nil {
+		panic(err)
+	}
 
-		return c.String(http.StatusOK, "Ok")
-	})
+	registerRoutes(e, store, defaultMaxConcurrent, policy, sessions)
 
 	return e
 }
@@ -48,6 +61,7 @@ func TestHandleCurl_ValidRequest(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -71,6 +85,7 @@ func TestHandleCurl_InvalidContentType(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	// Don't set Content-Type header
 	rec := httptest.NewRecorder()
 
@@ -88,6 +103,7 @@ func TestHandleCurl_InvalidJSON(t *testing.T) {
 	e := setupTestServer()
 
 	req := httptest.NewRequest(http.MethodPost, "/curl", strings.NewReader("invalid json"))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -111,6 +127,7 @@ func TestHandleCurl_WithTimeout(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl?timeout=5s", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -129,6 +146,7 @@ func TestHandleCurl_InvalidTimeout(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl?timeout=invalid", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -152,6 +170,7 @@ func TestHandleCurl_Base64Response(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl?base64=true", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -177,6 +196,7 @@ func TestHandleCurl_PlainTextResponse(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl?plain=true", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/plain")
 	rec := httptest.NewRecorder()
@@ -200,6 +220,7 @@ func TestHandleCurl_POSTRequest(t *testing.T) {
 	jsonData, _ := json.Marshal(requestData)
 
 	req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 
@@ -213,6 +234,158 @@ func TestHandleCurl_POSTRequest(t *testing.T) {
 	assert.Contains(t, response, "result")
 }
 
+func TestHandleCurl_RawCommand(t *testing.T) {
+	e := setupTestServer()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+
+	requestData := map[string]interface{}{
+		"cmd": "curl -X POST -H 'Content-Type: application/json' -d '{\"test\":\"data\"}' " + target.URL,
+	}
+
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?backend=native", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response["result"])
+}
+
+func TestHandleCurl_RawCommand_UnauthorizedOption(t *testing.T) {
+	e := setupTestServer()
+
+	requestData := map[string]interface{}{
+		"cmd": "curl --output /etc/passwd https://example.com",
+	}
+
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Contains(t, response["error"], "unauthorized curl option")
+}
+
+func TestHandleCurl_DryRun(t *testing.T) {
+	e := setupTestServer()
+
+	requestData := map[string]interface{}{
+		"-X":         "POST",
+		"-H":         "Content-Type: application/json",
+		"-d":         `{"name":"o'brien"}`,
+		"--location": "https://example.com/create",
+	}
+
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?dryrun=true", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	command := response["command"]
+	assert.True(t, strings.HasPrefix(command, "curl "))
+	assert.Contains(t, command, "'https://example.com/create'")
+	assert.Contains(t, command, `'{"name":"o'\''brien"}'`)
+}
+
+func TestHandleCurl_DryRun_DoesNotExecute(t *testing.T) {
+	e := setupTestServer()
+
+	// This target doesn't exist; if dryrun executed it, the request would
+	// fail with a connection/DNS error instead of returning a command.
+	requestData := map[string]interface{}{
+		"--location": "https://this-host-should-never-be-dialed.invalid/",
+	}
+
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?dryrun=true", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]string
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Contains(t, response["command"], "this-host-should-never-be-dialed.invalid")
+}
+
+func TestHandleCurl_Trace(t *testing.T) {
+	e := setupTestServer()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer target.Close()
+
+	requestData := map[string]interface{}{
+		"--location": target.URL,
+	}
+
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?backend=native&trace=true", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Result string `json:"result"`
+		Trace  struct {
+			StatusCode int     `json:"status_code"`
+			TotalMs    float64 `json:"total_ms"`
+			RemoteAddr string  `json:"remote_addr"`
+		} `json:"trace"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response.Result)
+	assert.Equal(t, http.StatusOK, response.Trace.StatusCode)
+	assert.NotEmpty(t, response.Trace.RemoteAddr)
+}
+
 func TestWaitingEndpoint_ValidMilliseconds(t *testing.T) {
 	e := setupTestServer()
 
@@ -272,6 +445,174 @@ func TestWaitingEndpoint_ZeroMilliseconds(t *testing.T) {
 	assert.Equal(t, "Ok", rec.Body.String())
 }
 
+func TestHandleCurl_SessionSharesCookiesAcrossRequests(t *testing.T) {
+	e := setupTestServer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/set" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		cookie, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("authenticated as " + cookie.Value))
+	}))
+	defer server.Close()
+
+	setJSON, _ := json.Marshal(map[string]interface{}{"--location": server.URL + "/set"})
+	setReq := httptest.NewRequest(http.MethodPost, "/curl?session_id=test-session&backend=native", bytes.NewBuffer(setJSON))
+	setReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	setReq.Header.Set("Content-Type", "application/json")
+	setRec := httptest.NewRecorder()
+	e.ServeHTTP(setRec, setReq)
+	require.Equal(t, http.StatusOK, setRec.Code)
+
+	whoamiJSON, _ := json.Marshal(map[string]interface{}{"--location": server.URL + "/whoami"})
+	whoamiReq := httptest.NewRequest(http.MethodPost, "/curl?session_id=test-session&backend=native", bytes.NewBuffer(whoamiJSON))
+	whoamiReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	whoamiReq.Header.Set("Content-Type", "application/json")
+	whoamiRec := httptest.NewRecorder()
+	e.ServeHTTP(whoamiRec, whoamiReq)
+	require.Equal(t, http.StatusOK, whoamiRec.Code)
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(whoamiRec.Body.Bytes(), &response))
+	assert.Equal(t, "authenticated as abc123", response["result"])
+}
+
+func TestHandleCurl_RetriesOn503ThenSucceeds(t *testing.T) {
+	e := setupTestServer()
+
+	var requestCount int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		if requestCount <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	requestData := map[string]interface{}{"--location": server.URL}
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?backend=native&retries=3&retry_backoff=1ms&retry_max_backoff=5ms&retry_on=5xx", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Result   string `json:"result"`
+		Attempts int    `json:"attempts"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "ok", response.Result)
+	assert.Equal(t, 3, response.Attempts)
+	assert.Equal(t, 3, requestCount)
+}
+
+func TestHandleCurl_RetriesNotRequestedLeavesEnvelopeUnchanged(t *testing.T) {
+	e := setupTestServer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	requestData := map[string]interface{}{"--location": server.URL}
+	jsonData, _ := json.Marshal(requestData)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl?backend=native", bytes.NewBuffer(jsonData))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "ok", response["result"])
+	_, hasAttempts := response["attempts"]
+	assert.False(t, hasAttempts)
+}
+
+func TestHandleSessionCookies_ReturnsStoredCookies(t *testing.T) {
+	e := setupTestServer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	setJSON, _ := json.Marshal(map[string]interface{}{"--location": server.URL})
+	setReq := httptest.NewRequest(http.MethodPost, "/curl?session_id=cookie-check&backend=native", bytes.NewBuffer(setJSON))
+	setReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	setReq.Header.Set("Content-Type", "application/json")
+	setRec := httptest.NewRecorder()
+	e.ServeHTTP(setRec, setReq)
+	require.Equal(t, http.StatusOK, setRec.Code)
+
+	getReq := httptest.NewRequest(http.MethodGet, "/session/cookie-check", nil)
+	getReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	getRec := httptest.NewRecorder()
+	e.ServeHTTP(getRec, getReq)
+	require.Equal(t, http.StatusOK, getRec.Code)
+
+	var body struct {
+		Cookies []map[string]interface{} `json:"cookies"`
+	}
+	require.NoError(t, json.Unmarshal(getRec.Body.Bytes(), &body))
+	require.Len(t, body.Cookies, 1)
+	assert.Equal(t, "session", body.Cookies[0]["name"])
+	assert.Equal(t, "abc123", body.Cookies[0]["value"])
+}
+
+func TestHandleSessionDelete_ClearsSession(t *testing.T) {
+	e := setupTestServer()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123"})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	setJSON, _ := json.Marshal(map[string]interface{}{"--location": server.URL})
+	setReq := httptest.NewRequest(http.MethodPost, "/curl?session_id=to-delete&backend=native", bytes.NewBuffer(setJSON))
+	setReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	setReq.Header.Set("Content-Type", "application/json")
+	setRec := httptest.NewRecorder()
+	e.ServeHTTP(setRec, setReq)
+	require.Equal(t, http.StatusOK, setRec.Code)
+
+	delReq := httptest.NewRequest(http.MethodDelete, "/session/to-delete", nil)
+	delReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	delRec := httptest.NewRecorder()
+	e.ServeHTTP(delRec, delReq)
+	assert.Equal(t, http.StatusNoContent, delRec.Code)
+
+	getReq := httptest.NewRequest(http.MethodGet, "/session/to-delete", nil)
+	getReq.Header.Set("Authorization", "Bearer "+testAPIKey)
+	getRec := httptest.NewRecorder()
+	e.ServeHTTP(getRec, getReq)
+	assert.Equal(t, http.StatusNotFound, getRec.Code)
+}
+
 // Benchmark tests
 func BenchmarkHandleCurl_SimpleRequest(b *testing.B) {
 	e := setupTestServer()
@@ -285,6 +626,7 @@ func BenchmarkHandleCurl_SimpleRequest(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
 
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/time/rate"
+)
+
+// APIKey represents one caller's credentials and their configured quota.
+type APIKey struct {
+	Key   string
+	Label string
+	RPM   int // requests per minute
+}
+
+// loadAPIKeys parses a comma-separated list of "key:label[:rpm]" entries,
+// the format used by the HTTPCURL_API_KEYS env var, e.g.:
+//
+//	HTTPCURL_API_KEYS="sk_abc123:alice:300,sk_def456:bob:60"
+//
+// rpm defaults to defaultRPM when omitted. An empty raw string yields no
+// keys (not an error), since a freshly deployed instance may not have any
+// configured yet.
+func loadAPIKeys(raw string, defaultRPM int) ([]APIKey, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var keys []APIKey
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid API key entry %q: expected key:label[:rpm]", entry)
+		}
+
+		rpm := defaultRPM
+		if len(parts) >= 3 {
+			parsed, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid rate limit in entry %q: %w", entry, err)
+			}
+			rpm = parsed
+		}
+
+		keys = append(keys, APIKey{Key: parts[0], Label: parts[1], RPM: rpm})
+	}
+
+	return keys, nil
+}
+
+// keyStore holds the configured API keys and a per-key token-bucket rate
+// limiter.
+type keyStore struct {
+	mu       sync.Mutex
+	keys     map[string]APIKey
+	limiters map[string]*rate.Limiter
+}
+
+func newKeyStore(keys []APIKey) *keyStore {
+	s := &keyStore{
+		keys:     make(map[string]APIKey, len(keys)),
+		limiters: make(map[string]*rate.Limiter, len(keys)),
+	}
+	for _, k := range keys {
+		s.keys[k.Key] = k
+		s.limiters[k.Key] = rate.NewLimiter(rate.Limit(float64(k.RPM)/60), k.RPM)
+	}
+	return s
+}
+
+func (s *keyStore) authenticate(key string) (APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	apiKey, ok := s.keys[key]
+	return apiKey, ok
+}
+
+// allow reports whether key is within its rate limit. When it isn't, it
+// also returns how long the caller should wait before retrying.
+func (s *keyStore) allow(key string) (bool, time.Duration) {
+	s.mu.Lock()
+	limiter := s.limiters[key]
+	s.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, 0
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+const apiKeyContextKey = "apiKey"
+
+// requireAPIKey validates the Authorization: Bearer <key> header against
+// store, rejecting with 401 when it's missing or unrecognized.
+func requireAPIKey(store *keyStore) echo.MiddlewareFunc {
+	const bearerPrefix = "Bearer "
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(header, bearerPrefix) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing or malformed Authorization header"})
+			}
+
+			apiKey, ok := store.authenticate(strings.TrimPrefix(header, bearerPrefix))
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid API key"})
+			}
+
+			c.Set(apiKeyContextKey, apiKey)
+			return next(c)
+		}
+	}
+}
+
+// rateLimitByKey enforces store's per-key token-bucket limit, returning 429
+// with Retry-After when the caller (set by requireAPIKey) is over quota.
+func rateLimitByKey(store *keyStore) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			apiKey, _ := c.Get(apiKeyContextKey).(APIKey)
+
+			allowed, retryAfter := store.allow(apiKey.Key)
+			if !allowed {
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())+1))
+				return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// concurrencyLimit caps the number of in-flight requests allowed through to
+// next using a buffered channel as a semaphore, so a burst of callers can't
+// spin up enough concurrent curl subprocesses to exhaust file descriptors.
+func concurrencyLimit(max int) echo.MiddlewareFunc {
+	sem := make(chan struct{}, max)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			default:
+				c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+				return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "server is at capacity, try again shortly"})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func curlRequest(body map[string]interface{}, apiKey string) *http.Request {
+	jsonData, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/curl", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return req
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	keys, err := loadAPIKeys("sk_abc:alice:120,sk_def:bob", 300)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, APIKey{Key: "sk_abc", Label: "alice", RPM: 120}, keys[0])
+	assert.Equal(t, APIKey{Key: "sk_def", Label: "bob", RPM: 300}, keys[1])
+}
+
+func TestLoadAPIKeys_Empty(t *testing.T) {
+	keys, err := loadAPIKeys("", 300)
+	require.NoError(t, err)
+	assert.Nil(t, keys)
+}
+
+func TestLoadAPIKeys_Invalid(t *testing.T) {
+	_, err := loadAPIKeys("sk_abc", 300)
+	assert.Error(t, err)
+}
+
+func TestRequireAPIKey_MissingHeader(t *testing.T) {
+	e := echo.New()
+	store := newKeyStore([]APIKey{{Key: testAPIKey, Label: "test", RPM: 10000}})
+	registerRoutes(e, store, defaultMaxConcurrent, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/curl", nil)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequireAPIKey_InvalidKey(t *testing.T) {
+	e := echo.New()
+	store := newKeyStore([]APIKey{{Key: testAPIKey, Label: "test", RPM: 10000}})
+	registerRoutes(e, store, defaultMaxConcurrent, nil, nil)
+
+	req := curlRequest(map[string]interface{}{"--location": "https://example.com"}, "not-a-real-key")
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRateLimitByKey_HammersPastQuota(t *testing.T) {
+	e := echo.New()
+	store := newKeyStore([]APIKey{{Key: testAPIKey, Label: "test", RPM: 2}})
+	registerRoutes(e, store, defaultMaxConcurrent, nil, nil)
+
+	var sawOK, sawTooMany int
+	for i := 0; i < 5; i++ {
+		req := curlRequest(map[string]interface{}{"--location": "https://example.com"}, testAPIKey)
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		switch rec.Code {
+		case http.StatusTooManyRequests:
+			sawTooMany++
+			assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+		default:
+			sawOK++
+		}
+	}
+
+	assert.Greater(t, sawTooMany, 0, "hammering a 2rpm key should eventually trip the limiter")
+}
+
+func TestConcurrencyLimit_HammersPastCap(t *testing.T) {
+	e := echo.New()
+	store := newKeyStore([]APIKey{{Key: testAPIKey, Label: "test", RPM: 100000}})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	e.POST("/blocking", func(c echo.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	}, requireAPIKey(store), rateLimitByKey(store), concurrencyLimit(1))
+
+	var wg sync.WaitGroup
+	codes := make([]int, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			req := curlRequest(map[string]interface{}{"--location": "https://example.com"}, testAPIKey)
+			req.URL.Path = "/blocking"
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes[idx] = rec.Code
+		}(i)
+	}
+
+	// The first goroutine to win the single semaphore slot blocks inside the
+	// handler; let it get there, then give the other two a moment to arrive
+	// and be rejected for capacity before releasing it.
+	<-entered
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	var sawTooMany int
+	for _, code := range codes {
+		if code == http.StatusTooManyRequests {
+			sawTooMany++
+		}
+	}
+	assert.Greater(t, sawTooMany, 0, "hammering a capacity-1 server with concurrent requests should reject at least one")
+}
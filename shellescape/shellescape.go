@@ -0,0 +1,22 @@
+// Package shellescape quotes strings so they can be safely pasted into a
+// POSIX shell command line, e.g. for rendering a curl invocation for
+// display rather than executing it directly.
+package shellescape
+
+import "strings"
+
+// Quote wraps s in single quotes, the only POSIX quoting style under which
+// no character is special, escaping any embedded single quote as '\”
+// (close the quote, an escaped literal quote, reopen the quote).
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs quotes each of args and joins them with spaces.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,44 @@
+package shellescape
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote_Simple(t *testing.T) {
+	assert.Equal(t, "'hello'", Quote("hello"))
+}
+
+func TestQuote_Empty(t *testing.T) {
+	assert.Equal(t, "''", Quote(""))
+}
+
+func TestQuote_SingleQuote(t *testing.T) {
+	assert.Equal(t, `'it'\''s'`, Quote("it's"))
+}
+
+func TestQuote_DoubleQuotes(t *testing.T) {
+	assert.Equal(t, `'say "hi"'`, Quote(`say "hi"`))
+}
+
+func TestQuote_DollarSign(t *testing.T) {
+	assert.Equal(t, "'$HOME is not expanded'", Quote("$HOME is not expanded"))
+}
+
+func TestQuote_Backtick(t *testing.T) {
+	assert.Equal(t, "'`whoami` is not executed'", Quote("`whoami` is not executed"))
+}
+
+func TestQuote_Newline(t *testing.T) {
+	assert.Equal(t, "'line one\nline two'", Quote("line one\nline two"))
+}
+
+func TestQuote_MultipleSingleQuotes(t *testing.T) {
+	assert.Equal(t, `''\'''\'''\'''`, Quote("'''"))
+}
+
+func TestQuoteArgs(t *testing.T) {
+	got := QuoteArgs([]string{"-X", "POST", "--location", "https://example.com?a=b&c=d"})
+	assert.Equal(t, "'-X' 'POST' '--location' 'https://example.com?a=b&c=d'", got)
+}